@@ -14,6 +14,7 @@ import (
 	"bytes"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/tools/configtxlator/update"
 	"github.com/hyperledger/fabric/integration/nwo/commands"
 	"github.com/hyperledger/fabric/protos/common"
@@ -168,6 +169,49 @@ func UpdateOrdererConfig(n *Network, orderer *Orderer, channel string, current,
 	Eventually(ccb, n.EventuallyTimeout).Should(BeNumerically(">", currentBlockNumber))
 }
 
+// ProposeConfigUpdate computes a configuration update the same way
+// UpdateConfig does, but never submits it to the network or signs it with
+// any additional signers: it builds the channel config from updated's channel
+// group in-process with channelconfig.NewChannelConfig. Validation does not
+// inspect signatures, so this covers both peer- and orderer-signed updates.
+// It does not check mod policies, policy definitions, read/write set
+// versions, or consensus-specific metadata such as etcdraft consenters. It
+// returns the ConfigEnvelope when validation passes, or the error from
+// NewChannelConfig (for example, an invalid orderer batch size). Callers that
+// want a valid update to actually take effect should follow up with
+// UpdateConfig or UpdateOrdererConfig using the same current/updated pair.
+func ProposeConfigUpdate(channel string, current, updated *common.Config) (*common.ConfigEnvelope, error) {
+	// compute update
+	configUpdate, err := update.Compute(current, updated)
+	Expect(err).NotTo(HaveOccurred())
+	configUpdate.ChannelId = channel
+
+	signedEnvelope, err := utils.CreateSignedEnvelope(
+		common.HeaderType_CONFIG_UPDATE,
+		channel,
+		nil, // local signer
+		&common.ConfigUpdateEnvelope{ConfigUpdate: utils.MarshalOrPanic(configUpdate)},
+		0, // message version
+		0, // epoch
+	)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(signedEnvelope).NotTo(BeNil())
+
+	if err := validateConfigUpdate(updated); err != nil {
+		return nil, err
+	}
+
+	return &common.ConfigEnvelope{Config: updated, LastUpdate: signedEnvelope}, nil
+}
+
+// validateConfigUpdate builds a channel config from updated's channel group
+// with channelconfig.NewChannelConfig and returns its error, without
+// submitting anything to the network.
+func validateConfigUpdate(updated *common.Config) error {
+	_, err := channelconfig.NewChannelConfig(updated.ChannelGroup)
+	return err
+}
+
 // CurrentConfigBlockNumber retrieves the block number from the header of the
 // current config block. This can be used to detect when configuration change
 // has completed.
@@ -240,6 +284,170 @@ func RemoveConsenter(n *Network, peer *Peer, orderer *Orderer, channel string, c
 	})
 }
 
+// ConfigPair bundles the current and desired configuration for a single
+// config update targeting the same channel. It is the unit of work accepted
+// by UpdateConfigBatch and UpdateOrdererConfigBatch. Batched ConfigPairs must
+// form a chain: each pair's Updated must equal the following pair's Current,
+// so that a single ConfigUpdate can be computed against the fully composed
+// final configuration.
+type ConfigPair struct {
+	Current *common.Config
+	Updated *common.Config
+}
+
+// UpdateConfigBatch computes a single ConfigUpdate via update.Compute against
+// the fully composed target of the given ConfigPairs (the first pair's
+// Current against the last pair's Updated), signs it once with all required
+// additionalSigners, and submits it as a single envelope, blocking only once
+// for the combined update to commit.
+func UpdateConfigBatch(n *Network, orderer *Orderer, channel string, configs []ConfigPair, submitter *Peer, additionalSigners ...*Peer) {
+	configUpdate := composeConfigUpdate(channel, configs)
+
+	tempDir, err := ioutil.TempDir("", "updateConfigBatch")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+
+	signedEnvelope, err := utils.CreateSignedEnvelope(
+		common.HeaderType_CONFIG_UPDATE,
+		channel,
+		nil, // local signer
+		&common.ConfigUpdateEnvelope{ConfigUpdate: utils.MarshalOrPanic(configUpdate)},
+		0, // message version
+		0, // epoch
+	)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(signedEnvelope).NotTo(BeNil())
+
+	updateFile := filepath.Join(tempDir, "update.pb")
+	err = ioutil.WriteFile(updateFile, utils.MarshalOrPanic(signedEnvelope), 0600)
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, signer := range additionalSigners {
+		sess, err := n.PeerAdminSession(signer, commands.SignConfigTx{File: updateFile})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	}
+
+	// get current configuration block number
+	currentBlockNumber := CurrentConfigBlockNumber(n, submitter, orderer, channel)
+
+	sess, err := n.PeerAdminSession(submitter, commands.ChannelUpdate{
+		ChannelID: channel,
+		Orderer:   n.OrdererAddress(orderer, ListenPort),
+		File:      updateFile,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	Expect(sess.Err).To(gbytes.Say("Successfully submitted channel update"))
+
+	// wait for the block to be committed
+	ccb := func() uint64 { return CurrentConfigBlockNumber(n, submitter, orderer, channel) }
+	Eventually(ccb, n.EventuallyTimeout).Should(BeNumerically(">", currentBlockNumber))
+}
+
+// UpdateOrdererConfigBatch is the orderer-signed counterpart to
+// UpdateConfigBatch: it computes a single ConfigUpdate against the fully
+// composed target of the given ConfigPairs and submits it as one envelope
+// signed by the required orderer signers, blocking only once for the
+// combined update to commit.
+func UpdateOrdererConfigBatch(n *Network, orderer *Orderer, channel string, configs []ConfigPair, submitter *Peer, additionalSigners ...*Orderer) {
+	configUpdate := composeConfigUpdate(channel, configs)
+
+	tempDir, err := ioutil.TempDir("", "updateOrdererConfigBatch")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+
+	signedEnvelope, err := utils.CreateSignedEnvelope(
+		common.HeaderType_CONFIG_UPDATE,
+		channel,
+		nil, // local signer
+		&common.ConfigUpdateEnvelope{ConfigUpdate: utils.MarshalOrPanic(configUpdate)},
+		0, // message version
+		0, // epoch
+	)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(signedEnvelope).NotTo(BeNil())
+
+	updateFile := filepath.Join(tempDir, "update.pb")
+	err = ioutil.WriteFile(updateFile, utils.MarshalOrPanic(signedEnvelope), 0600)
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, signer := range additionalSigners {
+		sess, err := n.OrdererAdminSession(signer, submitter, commands.SignConfigTx{File: updateFile})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	}
+
+	// get current configuration block number
+	currentBlockNumber := CurrentConfigBlockNumber(n, submitter, orderer, channel)
+
+	sess, err := n.PeerAdminSession(submitter, commands.ChannelUpdate{
+		ChannelID: channel,
+		Orderer:   n.OrdererAddress(orderer, ListenPort),
+		File:      updateFile,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	Expect(sess.Err).To(gbytes.Say("Successfully submitted channel update"))
+
+	// wait for the block to be committed
+	ccb := func() uint64 { return CurrentConfigBlockNumber(n, submitter, orderer, channel) }
+	Eventually(ccb, n.EventuallyTimeout).Should(BeNumerically(">", currentBlockNumber))
+}
+
+// composeConfigUpdate validates that configs forms a chain (each pair's
+// Updated equal to the next pair's Current) and computes a single
+// ConfigUpdate via update.Compute against the fully composed target: the
+// first pair's Current against the last pair's Updated. Computing one diff
+// against the composed target, rather than merging one diff per pair, keeps
+// each changed element's version exactly one ahead of the real current config
+// (groups whose membership is unchanged keep their current version), which is
+// what a single commit requires.
+func composeConfigUpdate(channel string, configs []ConfigPair) *common.ConfigUpdate {
+	Expect(configs).NotTo(BeEmpty())
+
+	for i := 1; i < len(configs); i++ {
+		Expect(proto.Equal(configs[i-1].Updated, configs[i].Current)).To(BeTrue(),
+			"ConfigPairs must chain: pair %d's Updated must equal pair %d's Current", i-1, i)
+	}
+
+	configUpdate, err := update.Compute(configs[0].Current, configs[len(configs)-1].Updated)
+	Expect(err).NotTo(HaveOccurred())
+	configUpdate.ChannelId = channel
+
+	return configUpdate
+}
+
+// BatchConsensusMetadataMutators applies each of the given
+// ConsensusMetadataMutators to a cumulative clone of the channel's current
+// config and returns one ConfigPair per mutator, suitable for passing to
+// UpdateOrdererConfigBatch alongside other ConfigPairs targeting the same channel.
+func BatchConsensusMetadataMutators(network *Network, peer *Peer, orderer *Orderer, channel string, mutateMetadata ...ConsensusMetadataMutator) []ConfigPair {
+	config := GetConfig(network, peer, orderer, channel)
+
+	pairs := make([]ConfigPair, 0, len(mutateMetadata))
+	for _, mutate := range mutateMetadata {
+		updatedConfig := proto.Clone(config).(*common.Config)
+
+		consensusTypeConfigValue := updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"]
+		consensusTypeValue := &protosorderer.ConsensusType{}
+		err := proto.Unmarshal(consensusTypeConfigValue.Value, consensusTypeValue)
+		Expect(err).NotTo(HaveOccurred())
+
+		consensusTypeValue.Metadata = mutate(consensusTypeValue.Metadata)
+
+		updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"] = &common.ConfigValue{
+			ModPolicy: "Admins",
+			Value:     utils.MarshalOrPanic(consensusTypeValue),
+		}
+
+		pairs = append(pairs, ConfigPair{Current: config, Updated: updatedConfig})
+		config = updatedConfig
+	}
+
+	return pairs
+}
+
 // ConsensusMetadataMutator receives ConsensusType.Metadata and mutates it
 type ConsensusMetadataMutator func([]byte) []byte
 
@@ -262,3 +470,86 @@ func UpdateConsensusMetadata(network *Network, peer *Peer, orderer *Orderer, cha
 
 	UpdateOrdererConfig(network, orderer, channel, config, updatedConfig, peer, orderer)
 }
+
+// MigrateConsensusType drives a channel through the maintenance-mode state
+// machine to swap its consensus type and metadata: it flips
+// ConsensusType.State to STATE_MAINTENANCE, submits the type/metadata change,
+// waits for it to commit, then flips State back to STATE_NORMAL. newMetadata
+// may be any proto.Message appropriate for newType (for example
+// *etcdraft.Metadata) and is marshaled with proto.Marshal.
+// onMaintenance, if provided, is invoked once the channel has entered
+// maintenance mode and before the type/metadata swap is submitted, so tests
+// can inject failures mid-migration to verify recovery.
+func MigrateConsensusType(n *Network, peer *Peer, orderer *Orderer, channel, newType string, newMetadata proto.Message, onMaintenance ...func()) {
+	marshaledMetadata, err := proto.Marshal(newMetadata)
+	Expect(err).NotTo(HaveOccurred())
+
+	setConsensusTypeState(n, peer, orderer, channel, protosorderer.ConsensusType_STATE_MAINTENANCE)
+
+	for _, hook := range onMaintenance {
+		hook()
+	}
+
+	config := GetConfig(n, peer, orderer, channel)
+	updatedConfig := proto.Clone(config).(*common.Config)
+
+	consensusTypeConfigValue := updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"]
+	consensusTypeValue := &protosorderer.ConsensusType{}
+	err = proto.Unmarshal(consensusTypeConfigValue.Value, consensusTypeValue)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(consensusTypeValue.State).To(Equal(protosorderer.ConsensusType_STATE_MAINTENANCE),
+		"channel must be in maintenance mode before migrating consensus type")
+
+	consensusTypeValue.Type = newType
+	consensusTypeValue.Metadata = marshaledMetadata
+
+	updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"] = &common.ConfigValue{
+		ModPolicy: "Admins",
+		Value:     utils.MarshalOrPanic(consensusTypeValue),
+	}
+
+	UpdateOrdererConfig(n, orderer, channel, config, updatedConfig, peer, orderer)
+
+	setConsensusTypeState(n, peer, orderer, channel, protosorderer.ConsensusType_STATE_NORMAL)
+}
+
+// setConsensusTypeState transitions ConsensusType.State on channel after
+// validating that the requested transition is legal.
+func setConsensusTypeState(n *Network, peer *Peer, orderer *Orderer, channel string, state protosorderer.ConsensusType_State) {
+	config := GetConfig(n, peer, orderer, channel)
+	updatedConfig := proto.Clone(config).(*common.Config)
+
+	consensusTypeConfigValue := updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"]
+	consensusTypeValue := &protosorderer.ConsensusType{}
+	err := proto.Unmarshal(consensusTypeConfigValue.Value, consensusTypeValue)
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(isLegalStateTransition(consensusTypeValue.State, state)).To(BeTrue(),
+		"illegal ConsensusType.State transition from %s to %s", consensusTypeValue.State, state)
+
+	consensusTypeValue.State = state
+
+	updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"] = &common.ConfigValue{
+		ModPolicy: "Admins",
+		Value:     utils.MarshalOrPanic(consensusTypeValue),
+	}
+
+	UpdateOrdererConfig(n, orderer, channel, config, updatedConfig, peer, orderer)
+}
+
+// isLegalStateTransition reports whether a channel may move from one
+// ConsensusType.State to another. The only legal transitions are staying put
+// or moving between STATE_NORMAL and STATE_MAINTENANCE.
+func isLegalStateTransition(from, to protosorderer.ConsensusType_State) bool {
+	if from == to {
+		return true
+	}
+	switch from {
+	case protosorderer.ConsensusType_STATE_NORMAL:
+		return to == protosorderer.ConsensusType_STATE_MAINTENANCE
+	case protosorderer.ConsensusType_STATE_MAINTENANCE:
+		return to == protosorderer.ConsensusType_STATE_NORMAL
+	default:
+		return false
+	}
+}
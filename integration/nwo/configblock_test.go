@@ -0,0 +1,68 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+	. "github.com/onsi/gomega"
+)
+
+// TestComposeConfigUpdateChainedPairs exercises the exact shape
+// BatchConsensusMetadataMutators produces when batching two or more
+// mutators: a chain of ConfigPairs where each pair's Updated is the next
+// pair's Current. A naive implementation that computes one ConfigUpdate per
+// pair and merges the resulting ConfigGroup trees inflates group/value
+// versions by one for every pair beyond the first; composeConfigUpdate must
+// instead diff directly against the fully composed target so the result is
+// exactly one version ahead of the real current config.
+func TestComposeConfigUpdateChainedPairs(t *testing.T) {
+	RegisterTestingT(t)
+
+	base := &common.Config{
+		Sequence: 1,
+		ChannelGroup: &common.ConfigGroup{
+			Version: 0,
+			Groups: map[string]*common.ConfigGroup{
+				"Orderer": {
+					Version: 0,
+					Values: map[string]*common.ConfigValue{
+						"ConsensusType": {Version: 0, Value: []byte("v0")},
+					},
+				},
+			},
+		},
+	}
+
+	afterFirstMutation := proto.Clone(base).(*common.Config)
+	afterFirstMutation.ChannelGroup.Groups["Orderer"].Values["ConsensusType"] = &common.ConfigValue{Value: []byte("v1")}
+
+	afterSecondMutation := proto.Clone(afterFirstMutation).(*common.Config)
+	afterSecondMutation.ChannelGroup.Groups["Orderer"].Values["ConsensusType"] = &common.ConfigValue{Value: []byte("v2")}
+
+	configs := []ConfigPair{
+		{Current: base, Updated: afterFirstMutation},
+		{Current: afterFirstMutation, Updated: afterSecondMutation},
+	}
+
+	configUpdate := composeConfigUpdate("testchannel", configs)
+	Expect(configUpdate.ChannelId).To(Equal("testchannel"))
+
+	ordererWriteGroup := configUpdate.WriteSet.Groups["Orderer"]
+	Expect(ordererWriteGroup).NotTo(BeNil())
+	Expect(ordererWriteGroup.Version).To(
+		Equal(base.ChannelGroup.Groups["Orderer"].Version+1),
+		"a single commit may only advance a group's version by one relative to the real current config, no matter how many ConfigPairs were batched to get there",
+	)
+
+	consensusType := ordererWriteGroup.Values["ConsensusType"]
+	Expect(consensusType).NotTo(BeNil())
+	Expect(consensusType.Value).To(Equal([]byte("v2")))
+	Expect(consensusType.Version).To(Equal(base.ChannelGroup.Groups["Orderer"].Values["ConsensusType"].Version + 1))
+}